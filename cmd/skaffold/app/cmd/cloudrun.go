@@ -0,0 +1,34 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmdCloudRun describes the `skaffold cloudrun` command group: operations that
+// act directly on already-deployed Cloud Run resources, outside the usual
+// build/deploy loop.
+func NewCmdCloudRun() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cloudrun",
+		Short: "Interact with Cloud Run resources deployed by Skaffold",
+	}
+	cmd.AddCommand(NewCmdCloudRunPromote())
+	cmd.AddCommand(NewCmdCloudRunDiff())
+	return cmd
+}
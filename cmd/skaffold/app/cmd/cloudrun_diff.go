@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/cloudrun"
+)
+
+type cloudRunDiffOptions struct {
+	project string
+	region  string
+	service string
+	job     string
+}
+
+// NewCmdCloudRunDiff describes `skaffold cloudrun diff`: print the normalized YAML of
+// a live Cloud Run resource. The command builds a fresh Deployer that has applied no
+// manifest itself, so it prints the live state only; it does not print a diff against
+// a desired manifest.
+func NewCmdCloudRunDiff() *cobra.Command {
+	opts := &cloudRunDiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Print the live state of a Cloud Run resource",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runCloudRunDiff(c, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.project, "project", "", "Google Cloud project of the resource")
+	cmd.Flags().StringVar(&opts.region, "region", "", "Cloud Run region of the resource")
+	cmd.Flags().StringVar(&opts.service, "service", "", "name of a Cloud Run service to diff")
+	cmd.Flags().StringVar(&opts.job, "job", "", "name of a Cloud Run job to diff")
+	return cmd
+}
+
+func runCloudRunDiff(c *cobra.Command, opts *cloudRunDiffOptions) error {
+	ctx := c.Context()
+	resName := cloudrun.RunResourceName{
+		Project: opts.project,
+		Region:  opts.region,
+		Service: opts.service,
+		Job:     opts.job,
+	}
+	d, err := cloudrun.NewDeployerForResource(ctx, resName)
+	if err != nil {
+		return err
+	}
+	return d.PrintCloudRunDiff(ctx, c.OutOrStdout(), resName)
+}
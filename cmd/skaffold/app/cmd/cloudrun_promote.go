@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/cloudrun"
+)
+
+type cloudRunPromoteOptions struct {
+	project   string
+	region    string
+	service   string
+	steps     []int64
+	stageWait time.Duration
+}
+
+// NewCmdCloudRunPromote describes `skaffold cloudrun promote`: walk a Cloud Run
+// service's traffic through a percent split (default 1%->10%->50%->100%),
+// checking SLIs between stages against DefaultSLIThresholds and rolling back on
+// breach. The same thresholds apply at every stage; they are not configurable.
+func NewCmdCloudRunPromote() *cobra.Command {
+	opts := &cloudRunPromoteOptions{}
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Progressively shift traffic to the latest Cloud Run revision",
+		RunE: func(c *cobra.Command, args []string) error {
+			return runCloudRunPromote(c, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.project, "project", "", "Google Cloud project of the service")
+	cmd.Flags().StringVar(&opts.region, "region", "", "Cloud Run region of the service")
+	cmd.Flags().StringVar(&opts.service, "service", "", "name of the Cloud Run service to promote")
+	cmd.Flags().Int64SliceVar(&opts.steps, "steps", []int64{1, 10, 50, 100}, "traffic percentages to step through")
+	cmd.Flags().DurationVar(&opts.stageWait, "stage-wait", 2*time.Minute, "time to wait at each stage before checking SLIs and moving on")
+	return cmd
+}
+
+func runCloudRunPromote(c *cobra.Command, opts *cloudRunPromoteOptions) error {
+	ctx := c.Context()
+	resName := cloudrun.RunResourceName{
+		Project: opts.project,
+		Region:  opts.region,
+		Service: opts.service,
+	}
+	d, err := cloudrun.NewDeployerForResource(ctx, resName)
+	if err != nil {
+		return err
+	}
+	return d.PromoteProgressively(ctx, c.OutOrStdout(), resName, opts.steps, cloudrun.DefaultSLIThresholds(), opts.stageWait)
+}
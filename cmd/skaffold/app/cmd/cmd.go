@@ -0,0 +1,35 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewSkaffoldCommand assembles the top-level `skaffold` command. Skaffold's other
+// command groups (build, dev, render, run, debug, diagnose, ...) live in sibling
+// files not part of this diff; this constructor only wires up the command groups
+// touched by the Cloud Run work, so `skaffold cloudrun promote`/`diff` are actually
+// reachable from the CLI instead of being dead code.
+func NewSkaffoldCommand() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "skaffold",
+		Short: "A tool that facilitates continuous development for Kubernetes and Cloud Run applications",
+	}
+	rootCmd.AddCommand(NewCmdCloudRun())
+	return rootCmd
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/run/v1"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/gcp"
+)
+
+// runClient is the narrow slice of the Cloud Run API the Deployer needs. Keeping it
+// this small lets deployToCloudRun/deployJob/promote/drift code be exercised by a
+// fake in unit tests instead of always hitting *-run.googleapis.com.
+type runClient interface {
+	GetService(name string) (*run.Service, error)
+	CreateService(parent string, svc *run.Service) (*run.Service, error)
+	ReplaceService(name string, svc *run.Service) (*run.Service, error)
+	DeleteService(name string) error
+
+	GetJob(name string) (*run.Job, error)
+	CreateJob(parent string, job *run.Job) (*run.Job, error)
+	ReplaceJob(name string, job *run.Job) (*run.Job, error)
+
+	RunJob(name string) (*run.Execution, error)
+	GetExecution(name string) (*run.Execution, error)
+}
+
+// googleRunClient implements runClient against the real Cloud Run API.
+type googleRunClient struct {
+	svc *run.APIService
+}
+
+func newGoogleRunClient(ctx context.Context, region string, clientOptions []option.ClientOption, useGcpOptions bool) (runClient, error) {
+	cOptions := clientOptions
+	if useGcpOptions {
+		cOptions = append(cOptions, option.WithEndpoint(fmt.Sprintf("%s-run.googleapis.com", region)))
+		cOptions = append(gcp.ClientOptions(ctx), cOptions...)
+	}
+	svc, err := run.NewService(ctx, cOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &googleRunClient{svc: svc}, nil
+}
+
+func (c *googleRunClient) GetService(name string) (*run.Service, error) {
+	return c.svc.Projects.Locations.Services.Get(name).Do()
+}
+
+func (c *googleRunClient) CreateService(parent string, svc *run.Service) (*run.Service, error) {
+	return c.svc.Projects.Locations.Services.Create(parent, svc).Do()
+}
+
+func (c *googleRunClient) ReplaceService(name string, svc *run.Service) (*run.Service, error) {
+	return c.svc.Projects.Locations.Services.ReplaceService(name, svc).Do()
+}
+
+func (c *googleRunClient) DeleteService(name string) error {
+	_, err := c.svc.Projects.Locations.Services.Delete(name).Do()
+	return err
+}
+
+func (c *googleRunClient) GetJob(name string) (*run.Job, error) {
+	return c.svc.Namespaces.Jobs.Get(name).Do()
+}
+
+func (c *googleRunClient) CreateJob(parent string, job *run.Job) (*run.Job, error) {
+	return c.svc.Namespaces.Jobs.Create(parent, job).Do()
+}
+
+func (c *googleRunClient) ReplaceJob(name string, job *run.Job) (*run.Job, error) {
+	return c.svc.Namespaces.Jobs.ReplaceJob(name, job).Do()
+}
+
+func (c *googleRunClient) RunJob(name string) (*run.Execution, error) {
+	return c.svc.Namespaces.Jobs.Run(name, &run.RunJobRequest{}).Do()
+}
+
+func (c *googleRunClient) GetExecution(name string) (*run.Execution, error) {
+	return c.svc.Namespaces.Executions.Get(name).Do()
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
@@ -33,7 +34,6 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/debug"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/label"
 	sErrors "github.com/GoogleContainerTools/skaffold/pkg/skaffold/errors"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/gcp"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/graph"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/manifest"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/log"
@@ -44,6 +44,10 @@ import (
 	"github.com/GoogleContainerTools/skaffold/proto/v1"
 )
 
+// defaultJobTimeout bounds how long we wait for a `jobs: { wait: true }` execution
+// to finish when the user hasn't set `jobs.timeout`.
+const defaultJobTimeout = 30 * time.Minute
+
 // Config contains config options needed for cloud run
 type Config interface {
 	PortForwardResources() []*latest.PortForwardResource
@@ -63,23 +67,73 @@ type Deployer struct {
 	Project string
 	Region  string
 
+	// jobExecution holds the `jobs:` settings from latest.CloudRunDeploy that control
+	// whether a deployed Job is run and waited on.
+	jobExecution jobExecutionConfig
+
+	// trafficPolicy holds the `traffic:` settings from latest.CloudRunDeploy, applied to
+	// service.Spec.Traffic before ReplaceService.
+	trafficPolicy *latest.CloudRunTrafficPolicy
+
+	// drift is the reconciliation loop for the `reconcile:` option on
+	// latest.CloudRunDeploy; it's started in Deploy and stopped in Cleanup.
+	drift *driftDetector
+
 	// additional client options for connecting to Cloud Run, used for tests
 	clientOptions []option.ClientOption
 	useGcpOptions bool
+
+	// testClient, when set, is returned by client() instead of building a real
+	// googleRunClient. Only ever set by tests.
+	testClient runClient
+}
+
+// jobExecutionConfig mirrors latest.CloudRunDeploy.Jobs: whether deployJob should also
+// run the Job, and for how long we're willing to wait for it to finish.
+type jobExecutionConfig struct {
+	runOnDeploy bool
+	wait        bool
+	timeout     time.Duration
 }
 
 // NewDeployer creates a new Deployer for Cloud Run from the Skaffold deploy config.
 func NewDeployer(cfg Config, labeller *label.DefaultLabeller, crDeploy *latest.CloudRunDeploy, configName string) (*Deployer, error) {
-	return &Deployer{
-		configName: configName,
-		Project:    crDeploy.ProjectID,
-		Region:     crDeploy.Region,
-		// TODO: implement logger for Cloud Run.
+	d := &Deployer{
+		configName:    configName,
+		Project:       crDeploy.ProjectID,
+		Region:        crDeploy.Region,
 		logger:        NewLoggerAggregator(cfg, labeller.GetRunID()),
 		accessor:      NewAccessor(cfg, labeller.GetRunID()),
 		labeller:      labeller,
 		useGcpOptions: true,
-	}, nil
+		trafficPolicy: crDeploy.Traffic,
+	}
+	d.drift = newDriftDetector(d, crDeploy.Reconcile)
+	if crDeploy.Jobs != nil {
+		timeout, err := time.ParseDuration(crDeploy.Jobs.Timeout)
+		if err != nil {
+			timeout = defaultJobTimeout
+		}
+		d.jobExecution = jobExecutionConfig{
+			runOnDeploy: crDeploy.Jobs.RunOnDeploy,
+			wait:        crDeploy.Jobs.Wait,
+			timeout:     timeout,
+		}
+	}
+	return d, nil
+}
+
+// NewDeployerForResource builds a minimal Deployer for commands like
+// `skaffold cloudrun promote`/`diff` that operate on an already-deployed resource
+// directly, outside of the usual build/render/deploy config and dev loop.
+func NewDeployerForResource(ctx context.Context, resName RunResourceName) (*Deployer, error) {
+	d := &Deployer{
+		Project:       resName.Project,
+		Region:        resName.Region,
+		useGcpOptions: true,
+	}
+	d.drift = newDriftDetector(d, false)
+	return d, nil
 }
 
 // Deploy creates a Cloud Run service using the provided manifest.
@@ -91,6 +145,7 @@ func (d *Deployer) Deploy(ctx context.Context, out io.Writer, artifacts []graph.
 			return err
 		}
 	}
+	d.drift.Start(ctx)
 	return nil
 }
 
@@ -105,9 +160,19 @@ func (d *Deployer) Dependencies() ([]string, error) {
 
 // Cleanup deletes the created Cloud Run services
 func (d *Deployer) Cleanup(ctx context.Context, out io.Writer, dryRun bool, byConfig manifest.ManifestListByConfig) error {
+	d.logger.Stop()
+	d.drift.Stop()
 	return d.deleteRunService(ctx, out, dryRun, byConfig.GetForConfig(d.configName))
 }
 
+// GetDriftEvents returns the drift events the reconciliation loop has observed so
+// far. The same events are also appended to GetStatusMonitor()'s DriftEvents as
+// they're recorded, so tooling that already holds a status.Monitor doesn't need a
+// separate Deployer reference to see them.
+func (d *Deployer) GetDriftEvents() []DriftEvent {
+	return d.drift.Events()
+}
+
 // GetDebugger Get the Debugger for Cloud Run. Not supported by this deployer.
 func (d *Deployer) GetDebugger() debug.Debugger {
 	return &debug.NoopDebugger{}
@@ -149,19 +214,29 @@ func (d *Deployer) getMonitor() *Monitor {
 	}
 	return d.monitor
 }
-func (d *Deployer) deployToCloudRun(ctx context.Context, out io.Writer, manifest []byte) error {
-	cOptions := d.clientOptions
-	if d.useGcpOptions {
-		cOptions = append(cOptions, option.WithEndpoint(fmt.Sprintf("%s-run.googleapis.com", d.Region)))
-		cOptions = append(gcp.ClientOptions(ctx), cOptions...)
+
+// client builds the runClient used to talk to Cloud Run, applying the region
+// endpoint and ADC-derived options the same way across deploy/delete/promote. Tests
+// inject a fakeRunClient via Deployer.testClient instead of going through this.
+func (d *Deployer) client(ctx context.Context) (runClient, error) {
+	if d.testClient != nil {
+		return d.testClient, nil
 	}
-	crclient, err := run.NewService(ctx, cOptions...)
+	crclient, err := newGoogleRunClient(ctx, d.Region, d.clientOptions, d.useGcpOptions)
 	if err != nil {
-		return sErrors.NewError(fmt.Errorf("unable to create Cloud Run Client"), &proto.ActionableErr{
+		return nil, sErrors.NewError(fmt.Errorf("unable to create Cloud Run Client"), &proto.ActionableErr{
 			Message: err.Error(),
 			ErrCode: proto.StatusCode_DEPLOY_GET_CLOUD_RUN_CLIENT_ERR,
 		})
 	}
+	return crclient, nil
+}
+
+func (d *Deployer) deployToCloudRun(ctx context.Context, out io.Writer, manifest []byte) error {
+	crclient, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
 	// figure out which type we have:
 	resource := &unstructured.Unstructured{}
 	if err = k8syaml.Unmarshal(manifest, resource); err != nil {
@@ -173,13 +248,16 @@ func (d *Deployer) deployToCloudRun(ctx context.Context, out io.Writer, manifest
 	var resName *RunResourceName
 	switch {
 	case resource.GetAPIVersion() == "serving.knative.dev/v1" && resource.GetKind() == "Service":
-		resName, err = d.deployService(crclient, manifest, out)
+		resName, err = d.deployService(ctx, crclient, manifest, out)
 		// the accessor only supports services. Jobs don't run by themselves so port forwarding doesn't make sense.
 		if resName != nil {
 			d.accessor.AddResource(*resName)
 		}
 	case resource.GetAPIVersion() == "run.googleapis.com/v1" && resource.GetKind() == "Job":
-		resName, err = d.deployJob(crclient, manifest, out)
+		resName, err = d.deployJob(ctx, crclient, manifest, out)
+		if err == nil && resName != nil && d.jobExecution.runOnDeploy {
+			err = d.runJobAndWait(ctx, crclient, out, *resName)
+		}
 	default:
 		err = sErrors.NewError(fmt.Errorf("unsupported Kind for Cloud Run Deployer: %s/%s", resource.GetAPIVersion(), resource.GetKind()),
 			&proto.ActionableErr{
@@ -196,7 +274,7 @@ func (d *Deployer) deployToCloudRun(ctx context.Context, out io.Writer, manifest
 	return nil
 }
 
-func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out io.Writer) (*RunResourceName, error) {
+func (d *Deployer) deployService(ctx context.Context, crclient runClient, manifest []byte, out io.Writer) (*RunResourceName, error) {
 	service := &run.Service{}
 	if err := k8syaml.Unmarshal(manifest, service); err != nil {
 		return nil, sErrors.NewError(fmt.Errorf("unable to unmarshal Cloud Run Service config"), &proto.ActionableErr{
@@ -204,13 +282,20 @@ func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out
 			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
 		})
 	}
-	if d.Project != "" {
+	switch {
+	case d.Project != "":
 		service.Metadata.Namespace = d.Project
-	} else if service.Metadata.Namespace == "" {
-		return nil, sErrors.NewError(fmt.Errorf("unable to detect project for Cloud Run"), &proto.ActionableErr{
-			Message: "No Google Cloud project found in Cloud Run Manifest or Skaffold Config",
-			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
-		})
+	case service.Metadata.Namespace != "":
+		// the manifest already carries a project, nothing to resolve.
+	default:
+		project, err := d.resolveProject(ctx)
+		if err != nil {
+			return nil, sErrors.NewError(fmt.Errorf("unable to detect project for Cloud Run: %w", err), &proto.ActionableErr{
+				Message: "No Google Cloud project found in Cloud Run Manifest or Skaffold Config",
+				ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
+			})
+		}
+		service.Metadata.Namespace = project
 	}
 	// we need to strip "skaffold.dev" from the run-id label because gcp labels don't support domains
 	runID, foundID := service.Metadata.Labels["skaffold.dev/run-id"]
@@ -225,6 +310,12 @@ func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out
 			service.Spec.Template.Metadata.Labels["run-id"] = runID
 		}
 	}
+	if err := applyTrafficPolicy(service, d.trafficPolicy); err != nil {
+		return nil, sErrors.NewError(fmt.Errorf("invalid Cloud Run traffic policy: %w", err), &proto.ActionableErr{
+			Message: err.Error(),
+			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
+		})
+	}
 	resName := RunResourceName{
 		Project: service.Metadata.Namespace,
 		Region:  d.Region,
@@ -235,8 +326,7 @@ func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out
 
 	sName := resName.String()
 	d.logger.AddResource(resName)
-	getCall := crclient.Projects.Locations.Services.Get(sName)
-	_, err := getCall.Do()
+	_, err := crclient.GetService(sName)
 
 	if err != nil {
 		gErr, ok := err.(*googleapi.Error)
@@ -247,11 +337,9 @@ func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out
 			})
 		}
 		// This is a new service, we need to create it
-		createCall := crclient.Projects.Locations.Services.Create(parent, service)
-		_, err = createCall.Do()
+		_, err = crclient.CreateService(parent, service)
 	} else {
-		replaceCall := crclient.Projects.Locations.Services.ReplaceService(sName, service)
-		_, err = replaceCall.Do()
+		_, err = crclient.ReplaceService(sName, service)
 	}
 	if err != nil {
 		return nil, sErrors.NewError(fmt.Errorf("error deploying Cloud Run Service: %s", err), &proto.ActionableErr{
@@ -259,10 +347,13 @@ func (d *Deployer) deployService(crclient *run.APIService, manifest []byte, out
 			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_UPDATE_SERVICE_ERR,
 		})
 	}
+	if applied, err := k8syaml.Marshal(service); err == nil {
+		d.drift.recordApplied(resName, applied)
+	}
 	return &resName, nil
 }
 
-func (d *Deployer) deployJob(crclient *run.APIService, manifest []byte, out io.Writer) (*RunResourceName, error) {
+func (d *Deployer) deployJob(ctx context.Context, crclient runClient, manifest []byte, out io.Writer) (*RunResourceName, error) {
 	job := &run.Job{}
 	if err := k8syaml.Unmarshal(manifest, job); err != nil {
 		return nil, sErrors.NewError(fmt.Errorf("unable to unmarshal Cloud Run Service config"), &proto.ActionableErr{
@@ -270,13 +361,20 @@ func (d *Deployer) deployJob(crclient *run.APIService, manifest []byte, out io.W
 			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
 		})
 	}
-	if d.Project != "" {
+	switch {
+	case d.Project != "":
 		job.Metadata.Namespace = d.Project
-	} else if job.Metadata.Namespace == "" {
-		return nil, sErrors.NewError(fmt.Errorf("unable to detect project for Cloud Run"), &proto.ActionableErr{
-			Message: "No Google Cloud project found in Cloud Run Manifest or Skaffold Config",
-			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
-		})
+	case job.Metadata.Namespace != "":
+		// the manifest already carries a project, nothing to resolve.
+	default:
+		project, err := d.resolveProject(ctx)
+		if err != nil {
+			return nil, sErrors.NewError(fmt.Errorf("unable to detect project for Cloud Run: %w", err), &proto.ActionableErr{
+				Message: "No Google Cloud project found in Cloud Run Manifest or Skaffold Config",
+				ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
+			})
+		}
+		job.Metadata.Namespace = project
 	}
 	// we need to strip "skaffold.dev" from the run-id label because gcp labels don't support domains
 	runID, foundID := job.Metadata.Labels["skaffold.dev/run-id"]
@@ -300,8 +398,8 @@ func (d *Deployer) deployJob(crclient *run.APIService, manifest []byte, out io.W
 	parent := fmt.Sprintf("namespaces/%s", job.Metadata.Namespace)
 
 	sName := resName.String()
-	getCall := crclient.Namespaces.Jobs.Get(sName)
-	_, err := getCall.Do()
+	d.logger.AddResource(resName)
+	_, err := crclient.GetJob(sName)
 
 	if err != nil {
 		gErr, ok := err.(*googleapi.Error)
@@ -312,11 +410,9 @@ func (d *Deployer) deployJob(crclient *run.APIService, manifest []byte, out io.W
 			})
 		}
 		// This is a new service, we need to create it
-		createCall := crclient.Namespaces.Jobs.Create(parent, job)
-		_, err = createCall.Do()
+		_, err = crclient.CreateJob(parent, job)
 	} else {
-		replaceCall := crclient.Namespaces.Jobs.ReplaceJob(sName, job)
-		_, err = replaceCall.Do()
+		_, err = crclient.ReplaceJob(sName, job)
 	}
 	if err != nil {
 		return nil, sErrors.NewError(fmt.Errorf("error deploying Cloud Run Job: %s", err), &proto.ActionableErr{
@@ -324,6 +420,9 @@ func (d *Deployer) deployJob(crclient *run.APIService, manifest []byte, out io.W
 			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_UPDATE_SERVICE_ERR,
 		})
 	}
+	if applied, err := k8syaml.Marshal(job); err == nil {
+		d.drift.recordApplied(resName, applied)
+	}
 	return &resName, nil
 }
 
@@ -350,11 +449,14 @@ func (d *Deployer) deleteRunService(ctx context.Context, out io.Writer, dryRun b
 	case service.Metadata.Namespace != "":
 		projectID = service.Metadata.Namespace
 	default:
-		// no project specified, we don't know what to delete.
-		return sErrors.NewError(fmt.Errorf("unable to determine Google Cloud Project"), &proto.ActionableErr{
-			Message: "No Google Cloud Project found in Cloud Run manifest or Skaffold Manifest.",
-			ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
-		})
+		resolved, err := d.resolveProject(ctx)
+		if err != nil {
+			return sErrors.NewError(fmt.Errorf("unable to determine Google Cloud Project: %w", err), &proto.ActionableErr{
+				Message: "No Google Cloud Project found in Cloud Run manifest or Skaffold Manifest.",
+				ErrCode: proto.StatusCode_DEPLOY_READ_MANIFEST_ERR,
+			})
+		}
+		projectID = resolved
 	}
 	parent := fmt.Sprintf("projects/%s/locations/%s", projectID, d.Region)
 	sName := fmt.Sprintf("%s/services/%s", parent, service.Metadata.Name)
@@ -362,16 +464,11 @@ func (d *Deployer) deleteRunService(ctx context.Context, out io.Writer, dryRun b
 		output.Yellow.Fprintln(out, sName)
 		return nil
 	}
-	crclient, err := run.NewService(ctx, append(gcp.ClientOptions(ctx), d.clientOptions...)...)
+	crclient, err := d.client(ctx)
 	if err != nil {
-		return sErrors.NewError(fmt.Errorf("unable to create Cloud Run Client"), &proto.ActionableErr{
-			Message: err.Error(),
-			ErrCode: proto.StatusCode_DEPLOY_GET_CLOUD_RUN_CLIENT_ERR,
-		})
+		return err
 	}
-	delCall := crclient.Projects.Locations.Services.Delete(sName)
-	_, err = delCall.Do()
-	if err != nil {
+	if err := crclient.DeleteService(sName); err != nil {
 		return sErrors.NewError(fmt.Errorf("unable to delete Cloud Run Service"), &proto.ActionableErr{
 			Message: err.Error(),
 			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_DELETE_SERVICE_ERR,
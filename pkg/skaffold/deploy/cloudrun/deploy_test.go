@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"google.golang.org/api/run/v1"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// fakeConfig is the minimal cloudrun.Config implementation the tests need; none of
+// these tests exercise log tailing so Tail() can just return false.
+type fakeConfig struct{}
+
+func (fakeConfig) PortForwardResources() []*latest.PortForwardResource { return nil }
+func (fakeConfig) PortForwardOptions() config.PortForwardOptions      { return config.PortForwardOptions{} }
+func (fakeConfig) Mode() config.RunMode                               { return config.RunModes.Dev }
+func (fakeConfig) Tail() bool                                         { return false }
+
+func newTestDeployer(t *testing.T, client runClient) *Deployer {
+	t.Helper()
+	d := &Deployer{
+		Project:       "test-project",
+		Region:        "us-central1",
+		logger:        NewLoggerAggregator(fakeConfig{}, "test-run-id"),
+		testClient:    client,
+		useGcpOptions: false,
+	}
+	d.drift = newDriftDetector(d, false)
+	return d
+}
+
+const serviceManifest = `
+apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: hello
+  labels:
+    skaffold.dev/run-id: abc123
+spec:
+  template:
+    metadata:
+      labels:
+        skaffold.dev/run-id: abc123
+`
+
+const jobManifest = `
+apiVersion: run.googleapis.com/v1
+kind: Job
+metadata:
+  name: migrate
+  labels:
+    skaffold.dev/run-id: abc123
+`
+
+func TestDeployServiceCreatesWhenMissing(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+
+	resName, err := d.deployService(context.Background(), client, []byte(serviceManifest), &bytes.Buffer{})
+	testutil.CheckError(t, false, err)
+	testutil.CheckDeepEqual(t, "hello", resName.Service)
+
+	created, ok := client.services["projects/test-project/locations/us-central1/services/hello"]
+	testutil.CheckDeepEqual(t, true, ok)
+	testutil.CheckDeepEqual(t, "abc123", created.Metadata.Labels["run-id"])
+	if _, stillPresent := created.Metadata.Labels["skaffold.dev/run-id"]; stillPresent {
+		t.Errorf("expected skaffold.dev/run-id label to be rewritten to run-id")
+	}
+	testutil.CheckDeepEqual(t, "abc123", created.Spec.Template.Metadata.Labels["run-id"])
+}
+
+func TestDeployServiceReplacesWhenPresent(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"}}
+	d := newTestDeployer(t, client)
+
+	_, err := d.deployService(context.Background(), client, []byte(serviceManifest), &bytes.Buffer{})
+	testutil.CheckError(t, false, err)
+
+	replaced := client.services[name]
+	testutil.CheckDeepEqual(t, "abc123", replaced.Metadata.Labels["run-id"])
+}
+
+func TestDeployServiceGetErrorOtherThanNotFound(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.seedError("GetService", name, conflictError())
+	d := newTestDeployer(t, client)
+
+	_, err := d.deployService(context.Background(), client, []byte(serviceManifest), &bytes.Buffer{})
+	testutil.CheckError(t, true, err)
+}
+
+func TestDeployJobCreatesWhenMissing(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+
+	resName, err := d.deployJob(context.Background(), client, []byte(jobManifest), &bytes.Buffer{})
+	testutil.CheckError(t, false, err)
+	testutil.CheckDeepEqual(t, "migrate", resName.Job)
+
+	created, ok := client.jobs["namespaces/test-project/jobs/migrate"]
+	testutil.CheckDeepEqual(t, true, ok)
+	testutil.CheckDeepEqual(t, "abc123", created.Metadata.Labels["run-id"])
+}
+
+func TestDeployJobReplacesWhenPresent(t *testing.T) {
+	client := newFakeRunClient()
+	name := "namespaces/test-project/jobs/migrate"
+	client.jobs[name] = &run.Job{Metadata: &run.ObjectMeta{Name: "migrate", Namespace: "test-project"}}
+	d := newTestDeployer(t, client)
+
+	_, err := d.deployJob(context.Background(), client, []byte(jobManifest), &bytes.Buffer{})
+	testutil.CheckError(t, false, err)
+
+	replaced := client.jobs[name]
+	testutil.CheckDeepEqual(t, "abc123", replaced.Metadata.Labels["run-id"])
+}
+
+func TestDeployToCloudRunUnsupportedKind(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+
+	manifest := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: oops\n")
+	err := d.deployToCloudRun(context.Background(), &bytes.Buffer{}, manifest)
+	testutil.CheckError(t, true, err)
+}
+
+func TestDeleteRunServiceDryRun(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+
+	manifestBytes, err := k8syaml.Marshal(&run.Service{
+		Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"},
+	})
+	testutil.CheckError(t, false, err)
+
+	var out bytes.Buffer
+	err = d.deleteRunService(context.Background(), &out, true, [][]byte{manifestBytes})
+	testutil.CheckError(t, false, err)
+	testutil.CheckContains(t, "projects/test-project/locations/us-central1/services/hello", out.String())
+
+	// dry-run must not have touched the fake backend.
+	testutil.CheckDeepEqual(t, 0, len(client.services))
+}
+
+func TestDeleteRunServiceDeletes(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"}}
+	d := newTestDeployer(t, client)
+
+	manifestBytes, err := k8syaml.Marshal(&run.Service{
+		Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"},
+	})
+	testutil.CheckError(t, false, err)
+
+	err = d.deleteRunService(context.Background(), &bytes.Buffer{}, false, [][]byte{manifestBytes})
+	testutil.CheckError(t, false, err)
+
+	if _, ok := client.services[name]; ok {
+		t.Errorf("expected service %s to be deleted from the fake backend", name)
+	}
+}
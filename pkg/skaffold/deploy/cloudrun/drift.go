@@ -0,0 +1,342 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/run/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/output"
+	"github.com/GoogleContainerTools/skaffold/proto/v1"
+)
+
+// reconcileInterval is how often the driftdetector re-fetches live resources and
+// compares them against the manifest we last applied.
+const reconcileInterval = 30 * time.Second
+
+// serverManagedFields are stripped from both the live resource and our applied
+// manifest before diffing, since Cloud Run (not the user) owns them: they'd show
+// up as permanent, uninteresting drift otherwise.
+var serverManagedFields = []string{"status"}
+
+var serverManagedAnnotations = []string{
+	"run.googleapis.com/operation-id",
+	"serving.knative.dev/creator",
+	"serving.knative.dev/lastModifier",
+}
+
+var serverManagedLabels = []string{
+	"cloud.googleapis.com/location",
+}
+
+// DriftEvent describes an observed difference between the manifest Skaffold applied
+// and the live state of the corresponding Cloud Run resource.
+type DriftEvent struct {
+	Resource   RunResourceName
+	Timestamp  time.Time
+	Diff       string
+	Reconciled bool
+}
+
+// driftDetector periodically diffs the live run.Service/run.Job for each deployed
+// resource against the manifest we applied, analogous to the reconciliation loop in
+// continuous-delivery agents. On drift it emits a DriftEvent and, when reconcile is
+// enabled, re-applies the desired manifest.
+type driftDetector struct {
+	d         *Deployer
+	reconcile bool
+
+	mu      sync.Mutex
+	applied map[string]appliedManifest // RunResourceName.String() -> last manifest we applied
+	events  []DriftEvent
+	cancel  context.CancelFunc
+}
+
+// appliedManifest pairs a resource name with the normalized manifest we last sent
+// Cloud Run for it, so the reconcile loop can both diff and, if needed, re-apply.
+type appliedManifest struct {
+	name     RunResourceName
+	manifest []byte
+}
+
+func newDriftDetector(d *Deployer, reconcile bool) *driftDetector {
+	return &driftDetector{
+		d:         d,
+		reconcile: reconcile,
+		applied:   map[string]appliedManifest{},
+	}
+}
+
+// recordApplied stores the normalized form of a manifest we just deployed, so the
+// next reconcile loop tick has something to diff the live resource against.
+func (dd *driftDetector) recordApplied(name RunResourceName, manifest []byte) {
+	normalized, err := normalizeManifest(manifest)
+	if err != nil {
+		return
+	}
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	dd.applied[name.String()] = appliedManifest{name: name, manifest: normalized}
+}
+
+// Events returns the drift events observed so far; Deployer.GetDriftEvents exposes
+// them, and reconcileOnce also mirrors each one into the status Monitor.
+func (dd *driftDetector) Events() []DriftEvent {
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	return append([]DriftEvent{}, dd.events...)
+}
+
+// Start begins the reconciliation loop, ticking every reconcileInterval until ctx is
+// cancelled or Stop is called.
+func (dd *driftDetector) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	dd.mu.Lock()
+	dd.cancel = cancel
+	dd.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dd.reconcileOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop tears down the reconciliation loop.
+func (dd *driftDetector) Stop() {
+	dd.mu.Lock()
+	cancel := dd.cancel
+	dd.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (dd *driftDetector) reconcileOnce(ctx context.Context) {
+	dd.mu.Lock()
+	applied := make(map[string]appliedManifest, len(dd.applied))
+	for k, v := range dd.applied {
+		applied[k] = v
+	}
+	dd.mu.Unlock()
+
+	crclient, err := dd.d.client(ctx)
+	if err != nil {
+		return
+	}
+
+	for key, am := range applied {
+		resName := am.name
+
+		var live []byte
+		if resName.Service != "" {
+			svc, err := crclient.GetService(key)
+			if err != nil {
+				continue
+			}
+			live, err = k8syaml.Marshal(svc)
+			if err != nil {
+				continue
+			}
+		} else {
+			job, err := crclient.GetJob(key)
+			if err != nil {
+				continue
+			}
+			live, err = k8syaml.Marshal(job)
+			if err != nil {
+				continue
+			}
+		}
+
+		normalizedLive, err := normalizeManifest(live)
+		if err != nil {
+			continue
+		}
+		if string(normalizedLive) == string(am.manifest) {
+			continue
+		}
+
+		diff := lineDiff(string(am.manifest), string(normalizedLive))
+		reconciled := false
+		if dd.reconcile && resName.Service != "" {
+			service := &run.Service{}
+			if err := k8syaml.Unmarshal(am.manifest, service); err == nil {
+				if _, err := crclient.ReplaceService(key, service); err == nil {
+					reconciled = true
+				}
+			}
+		} else if dd.reconcile {
+			job := &run.Job{}
+			if err := k8syaml.Unmarshal(am.manifest, job); err == nil {
+				if _, err := crclient.ReplaceJob(key, job); err == nil {
+					reconciled = true
+				}
+			}
+		}
+
+		ev := DriftEvent{Resource: resName, Timestamp: time.Now(), Diff: diff, Reconciled: reconciled}
+		dd.mu.Lock()
+		dd.events = append(dd.events, ev)
+		dd.mu.Unlock()
+
+		// mirror into the status Monitor, the same way deployToCloudRun appends to
+		// Resources, so GetStatusMonitor() surfaces drift alongside resource status.
+		dd.d.getMonitor().DriftEvents = append(dd.d.getMonitor().DriftEvents, ev)
+
+		event.Handle(&proto.Event{
+			EventType: &proto.Event_DriftEvent{
+				DriftEvent: &proto.DriftEvent{
+					Resource:   key,
+					Diff:       diff,
+					Reconciled: reconciled,
+				},
+			},
+		})
+	}
+}
+
+// normalizeManifest drops server-managed fields (status, generated annotations and
+// labels) from a Cloud Run resource manifest so the diff only reflects user intent.
+func normalizeManifest(manifest []byte) ([]byte, error) {
+	resource := &unstructured.Unstructured{}
+	if err := k8syaml.Unmarshal(manifest, resource); err != nil {
+		return nil, err
+	}
+	obj := resource.Object
+	for _, f := range serverManagedFields {
+		delete(obj, f)
+	}
+	stripKeys(obj, "metadata", "annotations", serverManagedAnnotations)
+	stripKeys(obj, "metadata", "labels", serverManagedLabels)
+	return k8syaml.Marshal(obj)
+}
+
+// lineDiff renders a minimal unified-style diff between two normalized manifests:
+// one line per entry, prefixed with "-" (only in applied), "+" (only in live), or
+// " " (unchanged). It's deliberately simple rather than a true LCS diff, which is
+// enough for the short, already-normalized YAML documents this package compares.
+func lineDiff(applied, live string) string {
+	appliedLines := strings.Split(strings.TrimRight(applied, "\n"), "\n")
+	liveLines := strings.Split(strings.TrimRight(live, "\n"), "\n")
+	remaining := make(map[string]int, len(liveLines))
+	for _, l := range liveLines {
+		remaining[l]++
+	}
+
+	var b strings.Builder
+	b.WriteString("--- applied\n+++ live\n")
+	for _, l := range appliedLines {
+		if remaining[l] > 0 {
+			remaining[l]--
+			fmt.Fprintf(&b, " %s\n", l)
+			continue
+		}
+		fmt.Fprintf(&b, "-%s\n", l)
+	}
+	appliedRemaining := make(map[string]int, len(appliedLines))
+	for _, l := range appliedLines {
+		appliedRemaining[l]++
+	}
+	for _, l := range liveLines {
+		if appliedRemaining[l] > 0 {
+			appliedRemaining[l]--
+			continue
+		}
+		fmt.Fprintf(&b, "+%s\n", l)
+	}
+	return b.String()
+}
+
+func stripKeys(obj map[string]interface{}, section, field string, keys []string) {
+	m, ok := obj[section].(map[string]interface{})
+	if !ok {
+		return
+	}
+	target, ok := m[field].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, k := range keys {
+		delete(target, k)
+	}
+}
+
+// PrintCloudRunDiff implements `skaffold cloudrun diff`: it fetches the live
+// resource and prints a diff against the manifest Skaffold last applied for it, using
+// the same unstructured decoding deployToCloudRun already does to tell services from
+// jobs. When there's no last-applied manifest to compare against (e.g. this process
+// never deployed the resource itself, only a standalone `diff` command was run
+// against it), it prints the normalized live state instead and says so.
+func (d *Deployer) PrintCloudRunDiff(ctx context.Context, out io.Writer, resName RunResourceName) error {
+	crclient, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	var live []byte
+	if resName.Service != "" {
+		svc, err := crclient.GetService(resName.String())
+		if err != nil {
+			return err
+		}
+		if live, err = k8syaml.Marshal(svc); err != nil {
+			return err
+		}
+	} else {
+		job, err := crclient.GetJob(resName.String())
+		if err != nil {
+			return err
+		}
+		if live, err = k8syaml.Marshal(job); err != nil {
+			return err
+		}
+	}
+
+	normalizedLive, err := normalizeManifest(live)
+	if err != nil {
+		return err
+	}
+
+	d.drift.mu.Lock()
+	am, ok := d.drift.applied[resName.String()]
+	d.drift.mu.Unlock()
+	if !ok {
+		output.Yellow.Fprintln(out, "No manifest was applied by this Skaffold run for this resource; showing live state:")
+		output.Default.Fprintln(out, string(normalizedLive))
+		return nil
+	}
+
+	output.Default.Fprint(out, lineDiff(string(am.manifest), string(normalizedLive)))
+	return nil
+}
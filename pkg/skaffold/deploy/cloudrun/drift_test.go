@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"google.golang.org/api/run/v1"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const liveServiceManifest = `
+apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: hello
+  annotations:
+    run.googleapis.com/operation-id: abc-123
+  labels:
+    cloud.googleapis.com/location: us-central1
+    run-id: abc123
+status:
+  url: https://hello-xyz.a.run.app
+`
+
+func TestNormalizeManifestStripsServerManagedFields(t *testing.T) {
+	normalized, err := normalizeManifest([]byte(liveServiceManifest))
+	testutil.CheckError(t, false, err)
+	testutil.CheckContains(t, "run-id: abc123", string(normalized))
+	if bytes.Contains(normalized, []byte("operation-id")) {
+		t.Errorf("expected server-managed annotation to be stripped, got: %s", normalized)
+	}
+	if bytes.Contains(normalized, []byte("cloud.googleapis.com/location")) {
+		t.Errorf("expected server-managed label to be stripped, got: %s", normalized)
+	}
+	if bytes.Contains(normalized, []byte("status:")) {
+		t.Errorf("expected status to be stripped, got: %s", normalized)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	applied := "a\nb\nc\n"
+	live := "a\nc\nd\n"
+
+	diff := lineDiff(applied, live)
+	testutil.CheckContains(t, "--- applied\n+++ live\n", diff)
+	testutil.CheckContains(t, " a\n", diff)
+	testutil.CheckContains(t, "-b\n", diff)
+	testutil.CheckContains(t, " c\n", diff)
+	testutil.CheckContains(t, "+d\n", diff)
+}
+
+func TestRecordAppliedAndEvents(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	d.drift.recordApplied(resName, []byte(liveServiceManifest))
+
+	d.drift.mu.Lock()
+	_, ok := d.drift.applied[resName.String()]
+	d.drift.mu.Unlock()
+	testutil.CheckDeepEqual(t, true, ok)
+
+	testutil.CheckDeepEqual(t, 0, len(d.drift.Events()))
+}
+
+func TestPrintCloudRunDiffNoAppliedManifest(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"}}
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	var out bytes.Buffer
+	err := d.PrintCloudRunDiff(context.Background(), &out, resName)
+	testutil.CheckError(t, false, err)
+	testutil.CheckContains(t, "No manifest was applied", out.String())
+}
+
+func TestPrintCloudRunDiffAgainstApplied(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{
+		Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project", Labels: map[string]string{"run-id": "abc123"}},
+	}
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	applied, err := normalizeManifest([]byte(liveServiceManifest))
+	testutil.CheckError(t, false, err)
+	d.drift.recordApplied(resName, applied)
+
+	var out bytes.Buffer
+	err = d.PrintCloudRunDiff(context.Background(), &out, resName)
+	testutil.CheckError(t, false, err)
+	testutil.CheckContains(t, "--- applied", out.String())
+}
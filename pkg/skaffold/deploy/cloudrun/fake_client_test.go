@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/run/v1"
+)
+
+// fakeRunClient is an in-memory runClient for hermetic unit tests: it stores
+// resources in maps keyed by their full resource name instead of calling
+// *-run.googleapis.com, and lets a test seed canned errors (404, 409,
+// quota-exceeded) for a given name.
+type fakeRunClient struct {
+	services   map[string]*run.Service
+	jobs       map[string]*run.Job
+	executions map[string]*run.Execution
+
+	// errs seeds a canned error to return the next time the given resource name is
+	// looked up, keyed by "<method>:<name>", e.g. "GetService:projects/p/.../svc".
+	errs map[string]error
+}
+
+func newFakeRunClient() *fakeRunClient {
+	return &fakeRunClient{
+		services:   map[string]*run.Service{},
+		jobs:       map[string]*run.Job{},
+		executions: map[string]*run.Execution{},
+		errs:       map[string]error{},
+	}
+}
+
+// seedError arranges for the next call to method for name to return err instead of
+// touching the in-memory maps.
+func (f *fakeRunClient) seedError(method, name string, err error) {
+	f.errs[method+":"+name] = err
+}
+
+// notFoundError builds the *googleapi.Error our callers type-assert on to detect a
+// missing resource (see deployService/deployJob's create-vs-replace branch).
+func notFoundError() error {
+	return &googleapi.Error{Code: http.StatusNotFound, Message: "not found"}
+}
+
+// conflictError simulates a concurrent modification, e.g. someone else replaced the
+// resource between our Get and our ReplaceService/ReplaceJob call.
+func conflictError() error {
+	return &googleapi.Error{Code: http.StatusConflict, Message: "conflict"}
+}
+
+// quotaExceededError simulates Cloud Run/Cloud Logging rate limiting.
+func quotaExceededError() error {
+	return &googleapi.Error{Code: http.StatusTooManyRequests, Message: "quota exceeded"}
+}
+
+// forbiddenError simulates an HTTP 403 with the given reason, e.g. "quotaExceeded"
+// for rate-limiting or "PERMISSION_DENIED" for an IAM failure. Both share a status
+// code, so isQuotaError has to look at reason to tell them apart.
+func forbiddenError(reason string) error {
+	return &googleapi.Error{
+		Code:    http.StatusForbidden,
+		Message: reason,
+		Errors:  []googleapi.ErrorItem{{Reason: reason, Message: reason}},
+	}
+}
+
+func (f *fakeRunClient) takeErr(method, name string) error {
+	key := method + ":" + name
+	if err, ok := f.errs[key]; ok {
+		delete(f.errs, key)
+		return err
+	}
+	return nil
+}
+
+func (f *fakeRunClient) GetService(name string) (*run.Service, error) {
+	if err := f.takeErr("GetService", name); err != nil {
+		return nil, err
+	}
+	svc, ok := f.services[name]
+	if !ok {
+		return nil, notFoundError()
+	}
+	return svc, nil
+}
+
+func (f *fakeRunClient) CreateService(parent string, svc *run.Service) (*run.Service, error) {
+	name := fakeServiceName(parent, svc)
+	if err := f.takeErr("CreateService", name); err != nil {
+		return nil, err
+	}
+	f.services[name] = svc
+	return svc, nil
+}
+
+func (f *fakeRunClient) ReplaceService(name string, svc *run.Service) (*run.Service, error) {
+	if err := f.takeErr("ReplaceService", name); err != nil {
+		return nil, err
+	}
+	f.services[name] = svc
+	return svc, nil
+}
+
+func (f *fakeRunClient) DeleteService(name string) error {
+	if err := f.takeErr("DeleteService", name); err != nil {
+		return err
+	}
+	delete(f.services, name)
+	return nil
+}
+
+func (f *fakeRunClient) GetJob(name string) (*run.Job, error) {
+	if err := f.takeErr("GetJob", name); err != nil {
+		return nil, err
+	}
+	job, ok := f.jobs[name]
+	if !ok {
+		return nil, notFoundError()
+	}
+	return job, nil
+}
+
+func (f *fakeRunClient) CreateJob(parent string, job *run.Job) (*run.Job, error) {
+	name := fakeJobName(parent, job)
+	if err := f.takeErr("CreateJob", name); err != nil {
+		return nil, err
+	}
+	f.jobs[name] = job
+	return job, nil
+}
+
+func (f *fakeRunClient) ReplaceJob(name string, job *run.Job) (*run.Job, error) {
+	if err := f.takeErr("ReplaceJob", name); err != nil {
+		return nil, err
+	}
+	f.jobs[name] = job
+	return job, nil
+}
+
+func (f *fakeRunClient) RunJob(name string) (*run.Execution, error) {
+	if err := f.takeErr("RunJob", name); err != nil {
+		return nil, err
+	}
+	execution := &run.Execution{
+		Metadata: &run.ObjectMeta{Name: name + "-" + "00001"},
+		Status: &run.ExecutionStatus{
+			Conditions: []*run.ExecutionCondition{
+				{Type: "Completed", Status: "True"},
+			},
+		},
+	}
+	f.executions[execution.Metadata.Name] = execution
+	return execution, nil
+}
+
+func (f *fakeRunClient) GetExecution(name string) (*run.Execution, error) {
+	if err := f.takeErr("GetExecution", name); err != nil {
+		return nil, err
+	}
+	execution, ok := f.executions[name]
+	if !ok {
+		return nil, notFoundError()
+	}
+	return execution, nil
+}
+
+func fakeServiceName(parent string, svc *run.Service) string {
+	return parent + "/services/" + svc.Metadata.Name
+}
+
+func fakeJobName(parent string, job *run.Job) string {
+	return parent + "/jobs/" + job.Metadata.Name
+}
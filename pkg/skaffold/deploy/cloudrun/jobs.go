@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/api/run/v1"
+
+	sErrors "github.com/GoogleContainerTools/skaffold/pkg/skaffold/errors"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/output"
+	"github.com/GoogleContainerTools/skaffold/proto/v1"
+)
+
+// executionPollInterval is how often we poll Namespaces.Executions.Get while
+// waiting for a Job execution to finish.
+const executionPollInterval = 2 * time.Second
+
+// runJobAndWait runs the Job we just deployed via Namespaces.Jobs.Run and, when
+// jobExecution.wait is set, polls the resulting Execution until it reaches a
+// terminal condition, streaming its logs through the aggregator in the meantime.
+func (d *Deployer) runJobAndWait(ctx context.Context, crclient runClient, out io.Writer, resName RunResourceName) error {
+	output.Default.Fprintln(out, "Running Cloud Run job:\n\t", resName.Job)
+
+	execution, err := crclient.RunJob(resName.String())
+	if err != nil {
+		return sErrors.NewError(fmt.Errorf("error running Cloud Run Job: %w", err), &proto.ActionableErr{
+			Message: err.Error(),
+			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR,
+		})
+	}
+	d.logger.AddResource(resName)
+
+	if !d.jobExecution.wait {
+		return nil
+	}
+
+	timeout := d.jobExecution.timeout
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return d.waitForExecution(waitCtx, crclient, out, execution.Metadata.Name, resName)
+}
+
+// waitForExecution polls the named Execution until it reports Completed or Failed,
+// then surfaces any per-task failures as a DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR.
+func (d *Deployer) waitForExecution(ctx context.Context, crclient runClient, out io.Writer, executionName string, resName RunResourceName) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return sErrors.NewError(fmt.Errorf("timed out waiting for Cloud Run Job execution %q: %w", executionName, ctx.Err()), &proto.ActionableErr{
+				Message: ctx.Err().Error(),
+				ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR,
+			})
+		default:
+		}
+
+		execution, err := crclient.GetExecution(executionName)
+		if err != nil {
+			return sErrors.NewError(fmt.Errorf("error checking Cloud Run Job execution status: %w", err), &proto.ActionableErr{
+				Message: err.Error(),
+				ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR,
+			})
+		}
+
+		if cond := executionCondition(execution, "Completed"); cond != nil {
+			switch cond.Status {
+			case "True":
+				output.Default.Fprintln(out, "Cloud Run job completed:\n\t", resName.Job)
+				return nil
+			case "False":
+				return jobExecutionFailure(resName, execution, cond)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			continue
+		case <-time.After(executionPollInterval):
+		}
+	}
+}
+
+// jobExecutionFailure turns a failed Execution's per-task counts into an
+// ActionableErr so CI pipelines can gate on the number of failed tasks.
+func jobExecutionFailure(resName RunResourceName, execution *run.Execution, cond *run.ExecutionCondition) error {
+	failed := int64(0)
+	succeeded := int64(0)
+	if execution.Status != nil {
+		failed = execution.Status.FailedCount
+		succeeded = execution.Status.SucceededCount
+	}
+	return sErrors.NewError(
+		fmt.Errorf("Cloud Run job %q failed: %d task(s) failed, %d succeeded: %s", resName.Job, failed, succeeded, cond.Message),
+		&proto.ActionableErr{
+			Message: cond.Message,
+			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR,
+		})
+}
+
+// executionCondition returns the named condition from an Execution's status, or
+// nil if it hasn't been reported yet.
+func executionCondition(execution *run.Execution, conditionType string) *run.ExecutionCondition {
+	if execution.Status == nil {
+		return nil
+	}
+	for _, cond := range execution.Status.Conditions {
+		if cond.Type == conditionType {
+			return cond
+		}
+	}
+	return nil
+}
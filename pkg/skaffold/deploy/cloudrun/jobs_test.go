@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/api/run/v1"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRunJobAndWaitNoWaitConfigured(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+
+	err := d.runJobAndWait(context.Background(), client, &bytes.Buffer{}, resName)
+	testutil.CheckError(t, false, err)
+
+	if _, ok := client.executions[resName.String()+"-00001"]; !ok {
+		t.Errorf("expected RunJob to have recorded an execution")
+	}
+}
+
+func TestRunJobAndWaitSucceeds(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+	d.jobExecution = jobExecutionConfig{wait: true, timeout: time.Second}
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+
+	err := d.runJobAndWait(context.Background(), client, &bytes.Buffer{}, resName)
+	testutil.CheckError(t, false, err)
+}
+
+func TestRunJobAndWaitRunJobError(t *testing.T) {
+	client := newFakeRunClient()
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+	client.seedError("RunJob", resName.String(), conflictError())
+	d := newTestDeployer(t, client)
+
+	err := d.runJobAndWait(context.Background(), client, &bytes.Buffer{}, resName)
+	testutil.CheckError(t, true, err)
+}
+
+func TestWaitForExecutionFailure(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+
+	client.executions["exec-1"] = &run.Execution{
+		Status: &run.ExecutionStatus{
+			FailedCount:    2,
+			SucceededCount: 1,
+			Conditions: []*run.ExecutionCondition{
+				{Type: "Completed", Status: "False", Message: "2 tasks failed"},
+			},
+		},
+	}
+
+	err := d.waitForExecution(context.Background(), client, &bytes.Buffer{}, "exec-1", resName)
+	testutil.CheckError(t, true, err)
+	testutil.CheckContains(t, "2 tasks failed", err.Error())
+}
+
+func TestWaitForExecutionTimesOut(t *testing.T) {
+	client := newFakeRunClient()
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+
+	client.executions["exec-1"] = &run.Execution{Status: &run.ExecutionStatus{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	err := d.waitForExecution(ctx, client, &bytes.Buffer{}, "exec-1", resName)
+	testutil.CheckError(t, true, err)
+}
+
+func TestExecutionCondition(t *testing.T) {
+	tests := []struct {
+		description string
+		execution   *run.Execution
+		want        *run.ExecutionCondition
+	}{
+		{
+			description: "no status reported yet",
+			execution:   &run.Execution{},
+			want:        nil,
+		},
+		{
+			description: "condition not yet present",
+			execution:   &run.Execution{Status: &run.ExecutionStatus{}},
+			want:        nil,
+		},
+		{
+			description: "condition present",
+			execution: &run.Execution{Status: &run.ExecutionStatus{
+				Conditions: []*run.ExecutionCondition{{Type: "Completed", Status: "True"}},
+			}},
+			want: &run.ExecutionCondition{Type: "Completed", Status: "True"},
+		},
+	}
+	for _, test := range tests {
+		testutil.Run(t, test.description, func(t *testutil.T) {
+			t.CheckDeepEqual(test.want, executionCondition(test.execution, "Completed"))
+		})
+	}
+}
+
+func TestJobExecutionFailure(t *testing.T) {
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Job: "migrate"}
+	execution := &run.Execution{Status: &run.ExecutionStatus{FailedCount: 3, SucceededCount: 1}}
+	cond := &run.ExecutionCondition{Type: "Completed", Status: "False", Message: "boom"}
+
+	err := jobExecutionFailure(resName, execution, cond)
+	testutil.CheckError(t, true, err)
+	testutil.CheckContains(t, "3 task(s) failed, 1 succeeded", err.Error())
+	testutil.CheckContains(t, "boom", err.Error())
+}
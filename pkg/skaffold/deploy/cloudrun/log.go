@@ -0,0 +1,330 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging/logadmin"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/graph"
+	kubernetesLog "github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes/log"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/output"
+)
+
+const (
+	// pollInterval is how often we ask Cloud Logging for new entries while tailing.
+	pollInterval = 1 * time.Second
+	// maxBackoff caps the exponential backoff applied when Cloud Logging returns quota errors.
+	maxBackoff = 30 * time.Second
+)
+
+// structuredPayload is the subset of a Cloud Run structured log entry we care about
+// when deciding how to render a line.
+type structuredPayload struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// LogAggregator streams Cloud Logging entries for the Cloud Run services and jobs
+// that the Deployer has deployed, in the same spirit as the Kubernetes log.LogAggregator:
+// one colorized stream per resource, tailed live when `cfg.Tail()` is set.
+type LogAggregator struct {
+	cfg   Config
+	runID string
+
+	clientOptions []option.ClientOption
+
+	colorPicker kubernetesLog.ColorPicker
+
+	mu        sync.Mutex
+	resources []RunResourceName
+	since     time.Time
+
+	out    io.Writer
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	muted bool
+}
+
+// NewLoggerAggregator creates a Cloud Run log aggregator. Resources are registered
+// with AddResource as the Deployer deploys them, and each gets its own tailing
+// goroutine once Start is called.
+func NewLoggerAggregator(cfg Config, runID string, clientOptions ...option.ClientOption) *LogAggregator {
+	return &LogAggregator{
+		cfg:           cfg,
+		runID:         runID,
+		clientOptions: clientOptions,
+		colorPicker:   kubernetesLog.NewColorPicker(),
+		since:         time.Now(),
+	}
+}
+
+// RegisterArtifacts is a no-op for Cloud Run: we key log streams off the deployed
+// resources' run-id label, not built images.
+func (l *LogAggregator) RegisterArtifacts(_ []graph.Artifact) {}
+
+// AddResource registers a Cloud Run service or job whose logs should be tailed.
+// Called as deployService/deployJob add resources to the accessor/monitor.
+func (l *LogAggregator) AddResource(name RunResourceName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.resources = append(l.resources, name)
+	l.colorPicker.AddResource(name.String())
+
+	if l.out != nil {
+		l.startTail(name)
+	}
+}
+
+// SetSince sets the earliest timestamp logs should be fetched from.
+func (l *LogAggregator) SetSince(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.since = t
+}
+
+// Start begins tailing logs for all currently registered resources to out.
+// If cfg.Tail() is false this returns without starting any tails: `skaffold run`
+// without --tail doesn't need a live log connection.
+func (l *LogAggregator) Start(ctx context.Context, out io.Writer) error {
+	l.mu.Lock()
+	l.out = out
+	ctx, cancel := context.WithCancel(ctx)
+	l.ctx = ctx
+	l.cancel = cancel
+	resources := append([]RunResourceName{}, l.resources...)
+	l.mu.Unlock()
+
+	if !l.cfg.Tail() {
+		return nil
+	}
+
+	for _, r := range resources {
+		l.startTailLocked(ctx, r)
+	}
+	return nil
+}
+
+// startTail is called with the lock held to kick off a tail for a resource
+// that's registered after Start has already been called. It reuses the same
+// cancellable context Start derived, so a later Stop() still cancels every
+// tail goroutine and wg.Wait() doesn't hang.
+func (l *LogAggregator) startTail(name RunResourceName) {
+	if !l.cfg.Tail() {
+		return
+	}
+	l.startTailLocked(l.ctx, name)
+}
+
+func (l *LogAggregator) startTailLocked(ctx context.Context, name RunResourceName) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.tail(ctx, name)
+	}()
+}
+
+// Mute silences log output without tearing down the tail goroutines.
+func (l *LogAggregator) Mute() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.muted = true
+}
+
+// Unmute resumes log output.
+func (l *LogAggregator) Unmute() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.muted = false
+}
+
+// Stop tears down all tailing goroutines.
+func (l *LogAggregator) Stop() {
+	l.mu.Lock()
+	cancel := l.cancel
+	l.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	l.wg.Wait()
+}
+
+// tail polls Cloud Logging for entries matching the resource's run-id label until
+// ctx is cancelled, backing off with jitter whenever the API returns a quota error.
+func (l *LogAggregator) tail(ctx context.Context, name RunResourceName) {
+	client, err := logadmin.NewClient(ctx, "projects/"+name.Project, l.clientOptions...)
+	if err != nil {
+		l.writeErr(name, fmt.Errorf("creating Cloud Logging client: %w", err))
+		return
+	}
+	defer client.Close()
+
+	backoff := pollInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		last, err := l.fetchAndPrint(ctx, client, name)
+		if err != nil {
+			if isQuotaError(err) {
+				jittered := backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+				time.Sleep(jittered)
+				backoff *= 2
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				continue
+			}
+			l.writeErr(name, err)
+			time.Sleep(pollInterval)
+			continue
+		}
+		backoff = pollInterval
+		if !last.IsZero() {
+			l.mu.Lock()
+			if last.After(l.since) {
+				l.since = last
+			}
+			l.mu.Unlock()
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// fetchAndPrint fetches log entries for name newer than the aggregator's `since`
+// cursor, decodes structured payloads, and writes one colorized line per entry.
+// It returns the timestamp of the last entry seen.
+func (l *LogAggregator) fetchAndPrint(ctx context.Context, client *logadmin.Client, name RunResourceName) (time.Time, error) {
+	l.mu.Lock()
+	since := l.since
+	l.mu.Unlock()
+
+	resourceType := "cloud_run_revision"
+	if name.Job != "" {
+		resourceType = "cloud_run_job"
+	}
+	filter := fmt.Sprintf(
+		`resource.type=%q AND resource.labels.location=%q AND labels."run-id"=%q AND timestamp>%q`,
+		resourceType, name.Region, l.runID, since.Format(time.RFC3339Nano),
+	)
+
+	// OldestFirst so a human watching --tail sees lines scroll forward in time;
+	// NewestFirst would print each poll's batch backwards.
+	it := client.Entries(ctx, logadmin.Filter(filter), logadmin.OldestFirst())
+	var newest time.Time
+	for {
+		entry, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return newest, err
+		}
+		if entry.Timestamp.After(newest) {
+			newest = entry.Timestamp
+		}
+		l.printEntry(name, entry)
+	}
+	return newest, nil
+}
+
+func (l *LogAggregator) printEntry(name RunResourceName, entry *logadmin.Entry) {
+	l.mu.Lock()
+	muted := l.muted
+	out := l.out
+	l.mu.Unlock()
+	if muted || out == nil {
+		return
+	}
+
+	headerColor := l.colorPicker.Pick(name.String())
+	message := formatPayload(entry.Payload)
+	headerColor.Fprintf(out, "[%s] %s\n", name.String(), message)
+}
+
+// formatPayload decodes a Cloud Run structured JSON payload (a map[string]interface{}
+// as returned by the Logging client) into a single display line, falling back to the
+// raw payload for plain-text entries.
+func formatPayload(payload interface{}) string {
+	switch p := payload.(type) {
+	case string:
+		return p
+	case map[string]interface{}:
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Sprintf("%v", p)
+		}
+		var sp structuredPayload
+		if err := json.Unmarshal(raw, &sp); err == nil && sp.Message != "" {
+			return sp.Message
+		}
+		return string(raw)
+	default:
+		return fmt.Sprintf("%v", p)
+	}
+}
+
+// isQuotaError reports whether err represents Cloud Logging rate-limiting that's
+// worth backing off and retrying for, as opposed to a permanent failure like
+// PERMISSION_DENIED (also an HTTP 403) that should surface to the user instead of
+// retrying forever.
+func isQuotaError(err error) bool {
+	gErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	if gErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if gErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, e := range gErr.Errors {
+		switch e.Reason {
+		case "rateLimitExceeded", "quotaExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LogAggregator) writeErr(name RunResourceName, err error) {
+	l.mu.Lock()
+	out := l.out
+	muted := l.muted
+	l.mu.Unlock()
+	if out == nil || muted {
+		return
+	}
+	output.Red.Fprintf(out, "[%s] error tailing logs: %v\n", name.String(), err)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestIsQuotaError(t *testing.T) {
+	tests := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{description: "quota exceeded (429) is a quota error", err: quotaExceededError(), expected: true},
+		{description: "other googleapi errors are not quota errors", err: conflictError(), expected: false},
+		{description: "not found (404) is not a quota error", err: notFoundError(), expected: false},
+		{description: "non-googleapi errors are not quota errors", err: errors.New("boom"), expected: false},
+		{description: "403 rateLimitExceeded is a quota error", err: forbiddenError("rateLimitExceeded"), expected: true},
+		{description: "403 userRateLimitExceeded is a quota error", err: forbiddenError("userRateLimitExceeded"), expected: true},
+		{description: "403 PERMISSION_DENIED is not a quota error", err: forbiddenError("PERMISSION_DENIED"), expected: false},
+	}
+	for _, test := range tests {
+		testutil.Run(t, test.description, func(t *testutil.T) {
+			t.CheckDeepEqual(test.expected, isQuotaError(test.err))
+		})
+	}
+}
+
+func TestFormatPayload(t *testing.T) {
+	tests := []struct {
+		description string
+		payload     interface{}
+		expected    string
+	}{
+		{
+			description: "plain string payload is passed through",
+			payload:     "hello world",
+			expected:    "hello world",
+		},
+		{
+			description: "structured payload with a message field uses the message",
+			payload:     map[string]interface{}{"message": "request handled", "severity": "INFO"},
+			expected:    "request handled",
+		},
+		{
+			description: "structured payload without a message field falls back to the raw JSON",
+			payload:     map[string]interface{}{"severity": "INFO"},
+			expected:    `{"severity":"INFO"}`,
+		},
+	}
+	for _, test := range tests {
+		testutil.Run(t, test.description, func(t *testutil.T) {
+			t.CheckDeepEqual(test.expected, formatPayload(test.payload))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"google.golang.org/api/option"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy/label"
+)
+
+// Monitor implements status.Monitor for Cloud Run: it tracks the resources the
+// Deployer has deployed (Resources, populated by deployToCloudRun) alongside the
+// drift events the reconciliation loop has observed (DriftEvents, populated by
+// driftDetector.reconcileOnce), so both are available to whatever `skaffold` calls
+// GetStatusMonitor.
+type Monitor struct {
+	labeller      *label.DefaultLabeller
+	clientOptions []option.ClientOption
+
+	Resources   []RunResourceName
+	DriftEvents []DriftEvent
+}
+
+// NewMonitor creates a Monitor for the given Deployer's labeller and client options.
+func NewMonitor(labeller *label.DefaultLabeller, clientOptions []option.ClientOption) *Monitor {
+	return &Monitor{
+		labeller:      labeller,
+		clientOptions: clientOptions,
+	}
+}
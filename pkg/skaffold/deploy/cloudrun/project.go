@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	"golang.org/x/oauth2/google"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/warnings"
+)
+
+// projectEnvVars are checked, in order, for a project ID before falling back to ADC
+// or gcloud. Cloud Build and many CI systems set one of these.
+var projectEnvVars = []string{"GOOGLE_CLOUD_PROJECT", "GCP_PROJECT"}
+
+// errNoProjectFound is returned when none of the discovery strategies find a project.
+var errNoProjectFound = errors.New("unable to detect project for Cloud Run")
+
+// resolveProject finds a Google Cloud project ID to deploy into when the user hasn't
+// set `projectID` in their Cloud Run manifest or Skaffold config. It tries, in order:
+// the GCE/Cloud Build metadata server, well-known env vars, the quota project on
+// Application Default Credentials, and finally `gcloud config get-value project`.
+// The result is cached on the Deployer so we only do this discovery once per run.
+func (d *Deployer) resolveProject(ctx context.Context) (string, error) {
+	if d.Project != "" {
+		return d.Project, nil
+	}
+
+	project, source, err := discoverProject(ctx)
+	if err != nil {
+		return "", err
+	}
+	warnings.Printf("No projectID set for Cloud Run deploy; using project %q detected from %s", project, source)
+
+	d.Project = project
+	return project, nil
+}
+
+func discoverProject(ctx context.Context) (project, source string, err error) {
+	if id, err := metadata.ProjectID(); err == nil && id != "" {
+		return id, "the GCE/Cloud Build metadata server", nil
+	}
+
+	if id, ok := firstNonEmptyEnv(projectEnvVars); ok {
+		return id, "the environment", nil
+	}
+
+	if creds, err := google.FindDefaultCredentials(ctx); err == nil && creds.ProjectID != "" {
+		return creds.ProjectID, "Application Default Credentials", nil
+	}
+
+	if id, err := gcloudConfigProject(ctx); err == nil && id != "" {
+		return id, "`gcloud config get-value project`", nil
+	}
+
+	return "", "", errNoProjectFound
+}
+
+func firstNonEmptyEnv(names []string) (string, bool) {
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// gcloudConfigProject shells out to `gcloud config get-value project` as a last
+// resort for local development without ADC or a metadata server.
+func gcloudConfigProject(ctx context.Context) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gcloud", "config", "get-value", "project")
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	project := strings.TrimSpace(stdout.String())
+	if project == "" || project == "(unset)" {
+		return "", errNoProjectFound
+	}
+	return project, nil
+}
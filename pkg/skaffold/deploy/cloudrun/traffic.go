@@ -0,0 +1,269 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/run/v1"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sErrors "github.com/GoogleContainerTools/skaffold/pkg/skaffold/errors"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/output"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/proto/v1"
+)
+
+// defaultPromotionSteps is the split walked by `skaffold cloudrun promote` when the
+// user doesn't configure their own stages: 1% -> 10% -> 50% -> 100%.
+var defaultPromotionSteps = []int64{1, 10, 50, 100}
+
+// SLIThresholds bounds the error-rate and p95 latency a stage is allowed to reach
+// before PromoteRevision rolls traffic back to the previously stable revision.
+type SLIThresholds struct {
+	MaxErrorRate  float64
+	MaxP95Latency time.Duration
+}
+
+// DefaultSLIThresholds is used by `skaffold cloudrun promote` for every stage; the
+// command offers no way to configure other thresholds. MaxErrorRate is 0.05 and
+// MaxP95Latency is 1s.
+func DefaultSLIThresholds() SLIThresholds {
+	return SLIThresholds{MaxErrorRate: 0.05, MaxP95Latency: time.Second}
+}
+
+// applyTrafficPolicy patches service.Spec.Traffic according to the `traffic:` block
+// on latest.CloudRunDeploy, ahead of ReplaceService. It supports splitting traffic by
+// percent across named revisions, pinning a tag-only revision with 0% traffic, and the
+// `latest` keyword for "whatever revision this deploy just created".
+func applyTrafficPolicy(service *run.Service, policy *latest.CloudRunTrafficPolicy) error {
+	if policy == nil || len(policy.Targets) == 0 {
+		return nil
+	}
+	if service.Spec == nil {
+		service.Spec = &run.ServiceSpec{}
+	}
+
+	var targets []*run.TrafficTarget
+	var totalPercent int64
+	for _, t := range policy.Targets {
+		target := &run.TrafficTarget{
+			Percent: t.Percent,
+			Tag:     t.Tag,
+		}
+		if t.Revision == "latest" || t.Revision == "" {
+			target.LatestRevision = true
+		} else {
+			target.RevisionName = t.Revision
+		}
+		totalPercent += t.Percent
+		targets = append(targets, target)
+	}
+	if totalPercent != 100 {
+		return fmt.Errorf("traffic policy percentages must add up to 100, got %d", totalPercent)
+	}
+
+	service.Spec.Traffic = targets
+	return nil
+}
+
+// PromoteRevision patches the live Service's traffic so that `percent` of requests
+// go to its most recent ready revision, putting the remainder on the other revision
+// currently serving the most traffic. It's the building block both `skaffold cloudrun promote`
+// and the automatic progressive-delivery loop below use to move traffic one step.
+func (d *Deployer) PromoteRevision(ctx context.Context, resName RunResourceName, percent int64) error {
+	crclient, err := d.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	sName := resName.String()
+	service, err := crclient.GetService(sName)
+	if err != nil {
+		return sErrors.NewError(fmt.Errorf("error fetching Cloud Run Service for promotion: %w", err), &proto.ActionableErr{
+			Message: err.Error(),
+			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_GET_SERVICE_ERR,
+		})
+	}
+
+	if service.Status == nil || service.Status.LatestReadyRevisionName == "" {
+		return sErrors.NewError(fmt.Errorf("Cloud Run Service %q has no ready revision to promote yet", sName), &proto.ActionableErr{
+			Message: "Service has no ready revision yet; wait for the deploy to finish before promoting",
+			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_GET_SERVICE_ERR,
+		})
+	}
+	if service.Spec == nil {
+		service.Spec = &run.ServiceSpec{}
+	}
+
+	latestRevision := service.Status.LatestReadyRevisionName
+	stableRevision := previousStableRevision(service, latestRevision)
+
+	service.Spec.Traffic = []*run.TrafficTarget{
+		{RevisionName: latestRevision, Percent: percent},
+	}
+	if percent < 100 && stableRevision != "" {
+		service.Spec.Traffic = append(service.Spec.Traffic, &run.TrafficTarget{
+			RevisionName: stableRevision,
+			Percent:      100 - percent,
+		})
+	}
+
+	if _, err := crclient.ReplaceService(sName, service); err != nil {
+		return sErrors.NewError(fmt.Errorf("error patching Cloud Run traffic split: %w", err), &proto.ActionableErr{
+			Message: err.Error(),
+			ErrCode: proto.StatusCode_DEPLOY_CLOUD_RUN_UPDATE_SERVICE_ERR,
+		})
+	}
+	return nil
+}
+
+// PromoteProgressively walks `steps` (e.g. 1, 10, 50, 100), calling PromoteRevision at
+// each stage and calling checkSLIs against thresholds before moving on. If an SLI
+// breaches its threshold, all traffic is moved back to the previously stable revision
+// (PromoteRevision with 0%) and the walk stops.
+func (d *Deployer) PromoteProgressively(ctx context.Context, out io.Writer, resName RunResourceName, steps []int64, thresholds SLIThresholds, stageWait time.Duration) error {
+	if len(steps) == 0 {
+		steps = defaultPromotionSteps
+	}
+
+	for _, percent := range steps {
+		output.Default.Fprintf(out, "Promoting %s to %d%% traffic\n", resName.Service, percent)
+		if err := d.PromoteRevision(ctx, resName, percent); err != nil {
+			return err
+		}
+
+		if percent == 100 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(stageWait):
+		}
+
+		healthy, err := d.checkSLIs(ctx, resName, thresholds.MaxErrorRate, thresholds.MaxP95Latency)
+		if err != nil {
+			return fmt.Errorf("checking Cloud Monitoring SLIs for %s: %w", resName.Service, err)
+		}
+		if !healthy {
+			output.Yellow.Fprintf(out, "SLI breach detected for %s, rolling back traffic\n", resName.Service)
+			return d.PromoteRevision(ctx, resName, 0)
+		}
+	}
+	return nil
+}
+
+// checkSLIs queries Cloud Monitoring for resName's error rate and p95 latency over
+// the trailing 5 minutes and reports whether both are within the given thresholds.
+// It's owned by the Deployer (rather than the status Monitor) since it's purely an
+// input to the progressive-delivery walk above, not part of `skaffold`'s general
+// resource-status reporting.
+func (d *Deployer) checkSLIs(ctx context.Context, resName RunResourceName, maxErrorRate float64, maxP95Latency time.Duration) (bool, error) {
+	client, err := monitoring.NewMetricClient(ctx, d.clientOptions...)
+	if err != nil {
+		return false, fmt.Errorf("creating Cloud Monitoring client: %w", err)
+	}
+	defer client.Close()
+
+	errorRate, err := queryLatestScalar(ctx, client, resName, "run.googleapis.com/request_count", `metric.labels.response_code_class!="2xx"`, monitoringpb.Aggregation_ALIGN_RATE)
+	if err != nil {
+		return false, fmt.Errorf("querying Cloud Run error rate: %w", err)
+	}
+	// request_latencies is a DISTRIBUTION-valued metric: ALIGN_PERCENTILE_95 has
+	// Cloud Monitoring collapse each series' distribution down to its 95th
+	// percentile for us, so the response comes back as a plain scalar we can read
+	// with GetDoubleValue below instead of one we'd have to compute ourselves.
+	p95Latency, err := queryLatestScalar(ctx, client, resName, "run.googleapis.com/request_latencies", "", monitoringpb.Aggregation_ALIGN_PERCENTILE_95)
+	if err != nil {
+		return false, fmt.Errorf("querying Cloud Run p95 latency: %w", err)
+	}
+
+	return errorRate <= maxErrorRate && time.Duration(p95Latency*float64(time.Millisecond)) <= maxP95Latency, nil
+}
+
+// slisWindow is how far back queryLatestScalar looks, and the alignment period it
+// collapses each series down to, when checking SLIs between promotion stages.
+const slisWindow = 5 * time.Minute
+
+// queryLatestScalar runs a Cloud Monitoring time-series query scoped to resName,
+// aligning each series with aligner so the result is always a scalar regardless of
+// the underlying metric kind, and returns the most recent point's value, or 0 if no
+// data has landed yet.
+func queryLatestScalar(ctx context.Context, client *monitoring.MetricClient, resName RunResourceName, metricType, extraFilter string, aligner monitoringpb.Aggregation_Aligner) (float64, error) {
+	filter := fmt.Sprintf(`metric.type=%q AND resource.labels.service_name=%q AND resource.labels.location=%q`,
+		metricType, resName.Service, resName.Region)
+	if extraFilter != "" {
+		filter = fmt.Sprintf("%s AND %s", filter, extraFilter)
+	}
+
+	now := time.Now()
+	it := client.ListTimeSeries(ctx, &monitoringpb.ListTimeSeriesRequest{
+		Name:   fmt.Sprintf("projects/%s", resName.Project),
+		Filter: filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(now.Add(-slisWindow)),
+			EndTime:   timestamppb.New(now),
+		},
+		Aggregation: &monitoringpb.Aggregation{
+			AlignmentPeriod:  durationpb.New(slisWindow),
+			PerSeriesAligner: aligner,
+		},
+		View: monitoringpb.ListTimeSeriesRequest_FULL,
+	})
+	ts, err := it.Next()
+	if err == iterator.Done {
+		// no data yet for this metric: treat as healthy rather than failing the promotion.
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(ts.Points) == 0 {
+		return 0, nil
+	}
+	point := ts.Points[0].Value
+	if v := point.GetDoubleValue(); v != 0 {
+		return v, nil
+	}
+	return float64(point.GetInt64Value()), nil
+}
+
+// previousStableRevision returns the revision currently serving the most traffic,
+// other than `exclude` (typically the brand-new revision we're about to promote).
+func previousStableRevision(service *run.Service, exclude string) string {
+	var best string
+	var bestPercent int64 = -1
+	for _, t := range service.Status.Traffic {
+		if t.RevisionName == exclude {
+			continue
+		}
+		if t.Percent > bestPercent {
+			bestPercent = t.Percent
+			best = t.RevisionName
+		}
+	}
+	return best
+}
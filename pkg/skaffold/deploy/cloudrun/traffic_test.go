@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudrun
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/api/run/v1"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestApplyTrafficPolicyNilPolicy(t *testing.T) {
+	service := &run.Service{}
+	err := applyTrafficPolicy(service, nil)
+	testutil.CheckError(t, false, err)
+	testutil.CheckDeepEqual(t, true, service.Spec == nil)
+}
+
+func TestApplyTrafficPolicySplitsAcrossRevisions(t *testing.T) {
+	service := &run.Service{}
+	policy := &latest.CloudRunTrafficPolicy{
+		Targets: []latest.CloudRunTrafficTarget{
+			{Revision: "latest", Percent: 90},
+			{Revision: "hello-00001", Percent: 10, Tag: "stable"},
+		},
+	}
+
+	err := applyTrafficPolicy(service, policy)
+	testutil.CheckError(t, false, err)
+	testutil.CheckDeepEqual(t, 2, len(service.Spec.Traffic))
+	testutil.CheckDeepEqual(t, true, service.Spec.Traffic[0].LatestRevision)
+	testutil.CheckDeepEqual(t, "hello-00001", service.Spec.Traffic[1].RevisionName)
+	testutil.CheckDeepEqual(t, "stable", service.Spec.Traffic[1].Tag)
+}
+
+func TestApplyTrafficPolicyRejectsBadSplit(t *testing.T) {
+	service := &run.Service{}
+	policy := &latest.CloudRunTrafficPolicy{
+		Targets: []latest.CloudRunTrafficTarget{
+			{Revision: "latest", Percent: 50},
+		},
+	}
+
+	err := applyTrafficPolicy(service, policy)
+	testutil.CheckError(t, true, err)
+}
+
+func TestPromoteRevisionNoReadyRevision(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"}}
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	err := d.PromoteRevision(context.Background(), resName, 50)
+	testutil.CheckError(t, true, err)
+}
+
+func TestPromoteRevisionSplitsTrafficAgainstStable(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{
+		Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"},
+		Status: &run.ServiceStatus{
+			LatestReadyRevisionName: "hello-00002",
+			Traffic: []*run.TrafficTarget{
+				{RevisionName: "hello-00001", Percent: 100},
+			},
+		},
+	}
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	err := d.PromoteRevision(context.Background(), resName, 10)
+	testutil.CheckError(t, false, err)
+
+	updated := client.services[name]
+	testutil.CheckDeepEqual(t, 2, len(updated.Spec.Traffic))
+	testutil.CheckDeepEqual(t, "hello-00002", updated.Spec.Traffic[0].RevisionName)
+	testutil.CheckDeepEqual(t, int64(10), updated.Spec.Traffic[0].Percent)
+	testutil.CheckDeepEqual(t, "hello-00001", updated.Spec.Traffic[1].RevisionName)
+	testutil.CheckDeepEqual(t, int64(90), updated.Spec.Traffic[1].Percent)
+}
+
+func TestPromoteRevisionFullCutoverDropsStable(t *testing.T) {
+	client := newFakeRunClient()
+	name := "projects/test-project/locations/us-central1/services/hello"
+	client.services[name] = &run.Service{
+		Metadata: &run.ObjectMeta{Name: "hello", Namespace: "test-project"},
+		Status: &run.ServiceStatus{
+			LatestReadyRevisionName: "hello-00002",
+			Traffic: []*run.TrafficTarget{
+				{RevisionName: "hello-00001", Percent: 100},
+			},
+		},
+	}
+	d := newTestDeployer(t, client)
+	resName := RunResourceName{Project: "test-project", Region: "us-central1", Service: "hello"}
+
+	err := d.PromoteRevision(context.Background(), resName, 100)
+	testutil.CheckError(t, false, err)
+
+	updated := client.services[name]
+	testutil.CheckDeepEqual(t, 1, len(updated.Spec.Traffic))
+	testutil.CheckDeepEqual(t, "hello-00002", updated.Spec.Traffic[0].RevisionName)
+}
+
+func TestPreviousStableRevision(t *testing.T) {
+	service := &run.Service{
+		Status: &run.ServiceStatus{
+			Traffic: []*run.TrafficTarget{
+				{RevisionName: "hello-00001", Percent: 20},
+				{RevisionName: "hello-00002", Percent: 80},
+			},
+		},
+	}
+
+	testutil.CheckDeepEqual(t, "hello-00002", previousStableRevision(service, "hello-00003"))
+	testutil.CheckDeepEqual(t, "hello-00001", previousStableRevision(service, "hello-00002"))
+}
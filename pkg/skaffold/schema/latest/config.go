@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latest
+
+// CloudRunDeploy contains the configuration needed for deploying to Cloud Run.
+// ProjectID and Region predate the `traffic`/`jobs`/`reconcile` options below.
+type CloudRunDeploy struct {
+	// ProjectID is the GCP Project used for default builds and deployments.
+	ProjectID string `yaml:"projectid,omitempty"`
+
+	// Region is the GCP region used for default builds and deployments.
+	Region string `yaml:"region,omitempty"`
+
+	// Traffic configures how traffic is split across revisions when deploying a
+	// Cloud Run Service. When it's not set Cloud Run sends the new revision 100%
+	// of traffic, same as if `traffic` had never been specified.
+	Traffic *CloudRunTrafficPolicy `yaml:"traffic,omitempty"`
+
+	// Jobs configures whether a deployed Cloud Run Job is also run, and whether
+	// Skaffold waits for that run to finish before considering the deploy done.
+	Jobs *CloudRunJobsConfig `yaml:"jobs,omitempty"`
+
+	// Reconcile, when `true`, has Skaffold periodically diff the live Cloud Run
+	// resource against the manifest it last applied, and re-apply on drift.
+	Reconcile bool `yaml:"reconcile,omitempty"`
+}
+
+// CloudRunTrafficPolicy configures how traffic is split across Cloud Run revisions.
+type CloudRunTrafficPolicy struct {
+	// Targets is the list of revisions traffic is split across. The `percent`
+	// fields across all targets must add up to 100.
+	Targets []CloudRunTrafficTarget `yaml:"targets,omitempty"`
+}
+
+// CloudRunTrafficTarget assigns a percentage of traffic, a tag, or both, to a
+// single Cloud Run revision.
+type CloudRunTrafficTarget struct {
+	// Revision is the name of the revision to route traffic to, or `latest`
+	// (the default) for whichever revision this deploy just created.
+	Revision string `yaml:"revision,omitempty"`
+
+	// Percent is the percentage of traffic this target should receive.
+	Percent int64 `yaml:"percent"`
+
+	// Tag, if set, assigns a tag-based URL to this revision.
+	Tag string `yaml:"tag,omitempty"`
+}
+
+// CloudRunJobsConfig controls whether a deployed Cloud Run Job is run as part of
+// `skaffold deploy`/`skaffold run`, and whether Skaffold waits for it to finish.
+type CloudRunJobsConfig struct {
+	// RunOnDeploy triggers an execution of the Job immediately after it's
+	// created or replaced.
+	RunOnDeploy bool `yaml:"runOnDeploy,omitempty"`
+
+	// Wait blocks the deploy until the triggered execution finishes.
+	Wait bool `yaml:"wait,omitempty"`
+
+	// Timeout bounds how long Skaffold waits for the execution to finish, e.g.
+	// `30m`. Defaults to 30 minutes.
+	Timeout string `yaml:"timeout,omitempty"`
+}
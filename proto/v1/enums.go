@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// StatusCode mirrors the subset of the protoc-generated StatusCode enum (from
+// proto/v1/skaffold.proto) that pkg/skaffold/deploy/cloudrun raises.
+// DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR is the one new code this series adds, so a
+// failed Job execution can be told apart from a generic deploy failure.
+type StatusCode int32
+
+const (
+	StatusCode_DEPLOY_READ_MANIFEST_ERR            StatusCode = 506
+	StatusCode_DEPLOY_GET_CLOUD_RUN_CLIENT_ERR     StatusCode = 750
+	StatusCode_DEPLOY_CLOUD_RUN_GET_SERVICE_ERR    StatusCode = 751
+	StatusCode_DEPLOY_CLOUD_RUN_UPDATE_SERVICE_ERR StatusCode = 752
+	StatusCode_DEPLOY_CLOUD_RUN_DELETE_SERVICE_ERR StatusCode = 753
+	StatusCode_DEPLOY_CLOUD_RUN_JOB_EXECUTION_ERR  StatusCode = 754
+)
+
+// ActionableErr pairs a human-readable message with a StatusCode so the Skaffold
+// CLI and IDE integrations can render remediation suggestions keyed off the code.
+type ActionableErr struct {
+	Message string
+	ErrCode StatusCode
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// Event is the envelope the event bus (pkg/skaffold/event) publishes; EventType
+// holds one of the Event_* wrapper types, mirroring the protoc-generated oneof in
+// proto/v1/skaffold.proto.
+type Event struct {
+	EventType isEvent_EventType
+}
+
+type isEvent_EventType interface {
+	isEvent_EventType()
+}
+
+// Event_DriftEvent wraps a DriftEvent as the payload of an Event, the same way the
+// generated oneof wrappers do for build/deploy/status-check events.
+type Event_DriftEvent struct {
+	DriftEvent *DriftEvent
+}
+
+func (*Event_DriftEvent) isEvent_EventType() {}
+
+// DriftEvent is published whenever the Cloud Run drift detector observes the live
+// resource diverge from the manifest Skaffold last applied.
+type DriftEvent struct {
+	Resource   string
+	Diff       string
+	Reconciled bool
+}